@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	StaticPath  = "static"
+	HeadersFile = "_headers"
+	HashLen     = 8
+)
+
+// assetManifest maps a static file's path relative to StaticPath (e.g.
+// "css/site.css") to its fingerprinted build path (e.g.
+// "css/site.a1b2c3d4.css"), as copied by the last copyStatic call.
+var assetManifest = map[string]string{}
+
+// copyStatic fingerprints every file under StaticPath with a short SHA-256
+// hash of its contents, copies it to build/static/<name>.<hash><ext>, and
+// writes a Cloudflare Pages _headers file giving the hashed assets a
+// long-lived Cache-Control.
+func copyStatic() error {
+	dst := filepath.Join(BuildPath, "static")
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+
+	assetManifest = make(map[string]string)
+
+	err := filepath.Walk(StaticPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, _ := filepath.Rel(StaticPath, path)
+		rel = filepath.ToSlash(rel)
+
+		hash, err := fileHash(path)
+		if err != nil {
+			return err
+		}
+
+		hashedRel := fingerprint(rel, hash)
+		outPath := filepath.Join(dst, filepath.FromSlash(hashedRel))
+		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+			return err
+		}
+
+		if err := copyFile(path, outPath); err != nil {
+			return err
+		}
+
+		assetManifest[rel] = hashedRel
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeHeaders()
+}
+
+func fingerprint(rel string, hash string) string {
+	ext := filepath.Ext(rel)
+	base := strings.TrimSuffix(rel, ext)
+	return fmt.Sprintf("%s.%s%s", base, hash, ext)
+}
+
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:HashLen], nil
+}
+
+// writeHeaders emits a Cloudflare Pages _headers file caching every
+// fingerprinted asset forever, since its filename changes whenever its
+// contents do.
+func writeHeaders() error {
+	f, err := os.Create(filepath.Join(BuildPath, HeadersFile))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	hashed := make([]string, 0, len(assetManifest))
+	for _, h := range assetManifest {
+		hashed = append(hashed, h)
+	}
+	sort.Strings(hashed)
+
+	for _, h := range hashed {
+		if _, err := fmt.Fprintf(f, "/static/%s\n  Cache-Control: public, max-age=31536000, immutable\n\n", h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assetPath is the {{ asset }} template helper: it resolves a static file's
+// path, relative to StaticPath, to its fingerprinted build path.
+func assetPath(name string) string {
+	hashed, ok := assetManifest[name]
+	if !ok {
+		return "/static/" + name
+	}
+	return "/static/" + hashed
+}
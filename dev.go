@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	DevAddr        = ":4000"
+	ReloadPath     = "/_reload"
+	DebounceWindow = 100 * time.Millisecond
+)
+
+const liveReloadScript = `<script>new EventSource("` + ReloadPath + `").onmessage=()=>location.reload()</script>`
+
+// LiveReload switches writePage into injecting the reload script. It is only
+// ever set by runDev.
+var LiveReload bool
+
+// runDev builds the site into a temp directory, serves it over HTTP, and
+// rebuilds on changes under ContentPath, TemplatesPath and StaticPath.
+func runDev(cfg SiteConfig) error {
+	tmpDir, err := os.MkdirTemp("", "wikiplum-dev-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	BuildPath = tmpDir
+	LiveReload = true
+
+	tmpl, err := mustLoadTemplates()
+	if err != nil {
+		return err
+	}
+
+	if err := rebuildAll(tmpl, cfg); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, dir := range []string{ContentPath, TemplatesPath, StaticPath} {
+		if err := watchRecursive(watcher, dir); err != nil {
+			return err
+		}
+	}
+
+	broker := newReloadBroker()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.Dir(tmpDir)))
+	mux.HandleFunc(ReloadPath, broker.serveSSE)
+
+	srv := &http.Server{Addr: DevAddr, Handler: mux}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Println("dev server error: ", err)
+		}
+	}()
+	fmt.Println("wikiplum dev server listening on", DevAddr)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+
+	changed := newChangeSet()
+	rebuild := debounce(DebounceWindow, func() {
+		paths := changed.drain()
+		if len(paths) == 0 {
+			return
+		}
+
+		tmpl, err = mustLoadTemplates()
+		if err != nil {
+			fmt.Println("error reloading templates: ", err)
+			return
+		}
+
+		if err := rebuildChanged(tmpl, cfg, paths); err != nil {
+			fmt.Println("rebuild error: ", err)
+			return
+		}
+
+		broker.notify()
+	})
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return shutdown(srv)
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) == 0 {
+				continue
+			}
+			changed.add(event.Name)
+			rebuild()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return shutdown(srv)
+			}
+			fmt.Println("watcher error: ", err)
+		case <-sigCh:
+			return shutdown(srv)
+		}
+	}
+}
+
+func shutdown(srv *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return srv.Shutdown(ctx)
+}
+
+// rebuildAll runs the full build, the same steps as build(), but against the
+// dev server's temp BuildPath.
+func rebuildAll(tmpl *template.Template, cfg SiteConfig) error {
+	if err := copyStatic(); err != nil {
+		return err
+	}
+
+	if err := buildPages(tmpl); err != nil {
+		return err
+	}
+
+	return buildFeed(cfg)
+}
+
+// rebuildChanged re-renders only the given paths, unless one of them lives
+// under TemplatesPath, in which case every page is rebuilt since templates
+// are shared across all of them. Every path in the batch is processed, not
+// just the first match, so a static change and a content change landing in
+// the same debounce window both take effect.
+func rebuildChanged(tmpl *template.Template, cfg SiteConfig, paths []string) error {
+	for _, path := range paths {
+		if strings.HasPrefix(path, TemplatesPath) {
+			return rebuildAll(tmpl, cfg)
+		}
+	}
+
+	needStatic := false
+	for _, path := range paths {
+		if strings.HasPrefix(path, StaticPath) {
+			needStatic = true
+			continue
+		}
+
+		if strings.HasSuffix(path, ".md") && filepath.Base(path) != SectionIndexFile {
+			if err := buildPage(tmpl, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	if needStatic {
+		return copyStatic()
+	}
+
+	return nil
+}
+
+func watchRecursive(w *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		return w.Add(path)
+	})
+}
+
+func injectLiveReload(html string) string {
+	if i := strings.LastIndex(html, "</body>"); i != -1 {
+		return html[:i] + liveReloadScript + html[i:]
+	}
+	return html + liveReloadScript
+}
+
+// changeSet coalesces the file paths touched between debounced rebuilds.
+type changeSet struct {
+	mu    sync.Mutex
+	paths map[string]struct{}
+}
+
+func newChangeSet() *changeSet {
+	return &changeSet{paths: make(map[string]struct{})}
+}
+
+func (c *changeSet) add(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paths[path] = struct{}{}
+}
+
+func (c *changeSet) drain() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	paths := make([]string, 0, len(c.paths))
+	for p := range c.paths {
+		paths = append(paths, p)
+	}
+	c.paths = make(map[string]struct{})
+	return paths
+}
+
+// debounce returns a function that runs fn after window has elapsed since
+// the last call, coalescing bursts of rapid calls (e.g. editor saves) into
+// a single invocation.
+func debounce(window time.Duration, fn func()) func() {
+	var mu sync.Mutex
+	var timer *time.Timer
+
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if timer != nil {
+			timer.Stop()
+		}
+		timer = time.AfterFunc(window, fn)
+	}
+}
+
+// reloadBroker fans out a reload notification to every connected SSE client.
+type reloadBroker struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+}
+
+func newReloadBroker() *reloadBroker {
+	return &reloadBroker{clients: make(map[chan struct{}]struct{})}
+}
+
+func (b *reloadBroker) serveSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.clients[ch] = struct{}{}
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, ch)
+		b.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case <-ch:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (b *reloadBroker) notify() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
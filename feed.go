@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/starillume/wikiplum/internal/frontmatter"
+)
+
+const (
+	SiteConfigPath = "site.yaml"
+	DateLayout     = "2006-01-02"
+)
+
+// SiteConfig holds the site-wide metadata needed to build a syndication feed.
+type SiteConfig struct {
+	Domain     string `yaml:"domain"`
+	Title      string `yaml:"title"`
+	Author     string `yaml:"author"`
+	StartDate  string `yaml:"start_date"`
+	PrettyURLs bool   `yaml:"pretty_urls"`
+}
+
+// FeedEntry is a single page eligible for the Atom feed and sitemap.
+type FeedEntry struct {
+	Title   string
+	Link    string
+	Summary string
+	Date    time.Time
+	Updated time.Time
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title   string   `xml:"title"`
+	ID      string   `xml:"id"`
+	Link    atomLink `xml:"link"`
+	Updated string   `xml:"updated"`
+	Summary string   `xml:"summary"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// loadSiteConfig reads the site-level config used to derive feed tag URIs.
+// A missing file is not an error: callers fall back to zero-value defaults.
+func loadSiteConfig() (SiteConfig, error) {
+	var cfg SiteConfig
+	data, err := os.ReadFile(SiteConfigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return cfg, err
+	}
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// buildFeed walks ContentPath collecting FeedEntry values for pages that
+// carry date/published/updated frontmatter, then writes the Atom feed and
+// sitemap to BuildPath.
+func buildFeed(cfg SiteConfig) error {
+	entries, err := collectFeedEntries()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Date.After(entries[j].Date)
+	})
+
+	if err := writeFeed(cfg, entries); err != nil {
+		return err
+	}
+
+	return writeSitemap(entries)
+}
+
+// publishedString normalizes the "published" frontmatter extra field to a
+// DateLayout string. TOML decodes a bare date like "2024-01-01" into a
+// time.Time when unmarshaled into a map[string]any, so a plain string
+// assertion misses the common unquoted case; YAML keeps it as a string.
+func publishedString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(DateLayout)
+	default:
+		return ""
+	}
+}
+
+func collectFeedEntries() ([]FeedEntry, error) {
+	var entries []FeedEntry
+
+	err := filepath.WalkDir(ContentPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".md") {
+			return err
+		}
+
+		md, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		fm, body, err := frontmatter.Parse(md)
+		if err != nil || fm.Draft {
+			return err
+		}
+
+		dateStr := fm.Date
+		if dateStr == "" {
+			dateStr = publishedString(fm.Extra["published"])
+		}
+		if dateStr == "" {
+			dateStr = fm.Updated
+		}
+		if dateStr == "" {
+			return nil
+		}
+
+		date, err := time.Parse(DateLayout, dateStr)
+		if err != nil {
+			return nil
+		}
+
+		updated := date
+		if fm.Updated != "" {
+			if t, err := time.Parse(DateLayout, fm.Updated); err == nil {
+				updated = t
+			}
+		}
+
+		summary := fm.Summary
+		if summary == "" {
+			html, err := renderMarkdown(body)
+			if err == nil {
+				summary = firstParagraph(html)
+			}
+		}
+
+		title := fm.Title
+		if title == "" {
+			title = filepath.Base(strings.TrimSuffix(path, ".md"))
+		}
+
+		entries = append(entries, FeedEntry{
+			Title:   title,
+			Link:    pageLink(path),
+			Summary: summary,
+			Date:    date,
+			Updated: updated,
+		})
+		return nil
+	})
+
+	return entries, err
+}
+
+// firstParagraph returns the text of the first <p> in rendered HTML, used as
+// a summary fallback when no `summary` frontmatter key is present.
+func firstParagraph(html string) string {
+	start := strings.Index(html, "<p>")
+	if start == -1 {
+		return ""
+	}
+	start += len("<p>")
+
+	end := strings.Index(html[start:], "</p>")
+	if end == -1 {
+		return ""
+	}
+
+	return html[start : start+end]
+}
+
+func tagURI(cfg SiteConfig, link string) string {
+	startDate := cfg.StartDate
+	if startDate == "" {
+		startDate = time.Now().Format(DateLayout)
+	}
+	return fmt.Sprintf("tag:%s,%s:%s", cfg.Domain, startDate, link)
+}
+
+func writeFeed(cfg SiteConfig, entries []FeedEntry) error {
+	feed := atomFeed{
+		Title:  cfg.Title,
+		ID:     tagURI(cfg, "/"),
+		Author: atomAuthor{Name: cfg.Author},
+	}
+
+	if len(entries) > 0 {
+		feed.Updated = entries[0].Updated.Format(time.RFC3339)
+	}
+
+	for _, e := range entries {
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:   e.Title,
+			ID:      tagURI(cfg, e.Link),
+			Link:    atomLink{Href: fmt.Sprintf("https://%s%s", cfg.Domain, e.Link)},
+			Updated: e.Updated.Format(time.RFC3339),
+			Summary: e.Summary,
+		})
+	}
+
+	f, err := os.Create(filepath.Join(BuildPath, "feed.xml"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	return enc.Encode(feed)
+}
+
+func writeSitemap(entries []FeedEntry) error {
+	set := urlSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+	for _, e := range entries {
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:     e.Link,
+			LastMod: e.Updated.Format(DateLayout),
+		})
+	}
+
+	f, err := os.Create(filepath.Join(BuildPath, "sitemap.xml"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	return enc.Encode(set)
+}
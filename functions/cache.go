@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/syumai/workers"
+	"github.com/syumai/workers/cloudflare/fetch"
+	"github.com/syumai/workers/cloudflare/kv"
+)
+
+const (
+	KVBindingName  = "WIKI_CACHE"
+	MainBranchTTL  = 5 * time.Minute
+	TagBranchTTL   = 24 * time.Hour
+	PurgeSecretEnv = "PURGE_SECRET"
+)
+
+// cacheEntry is what's stored in KV per branch/path: the upstream body
+// alongside the ETag it was served with, so a cache hit can be revalidated
+// with a conditional GET instead of re-fetching the body outright.
+type cacheEntry struct {
+	ETag string `json:"etag"`
+	Body []byte `json:"body"`
+}
+
+// fetchWikiPage serves path from the WIKI_CACHE KV namespace when possible,
+// revalidating against raw.githubusercontent.com with the stored ETag before
+// trusting it. branch controls the cache TTL: short for main so pushes show
+// up quickly, long for tagged branches that don't move.
+func fetchWikiPage(ctx context.Context, path string, branch string) ([]byte, error) {
+	ns, err := kv.NewNamespace(KVBindingName)
+	if err != nil {
+		return fetchUpstream(ctx, path, branch)
+	}
+
+	key := cacheKey(branch, path)
+
+	var cached cacheEntry
+	if raw, err := ns.GetString(ctx, key); err == nil && raw != "" {
+		json.Unmarshal([]byte(raw), &cached)
+	}
+
+	body, etag, notModified, err := fetchConditional(ctx, path, branch, cached.ETag)
+	if err != nil {
+		return nil, err
+	}
+
+	if notModified {
+		return cached.Body, nil
+	}
+
+	if err := putCacheEntry(ctx, ns, key, branch, etag, body); err != nil {
+		fmt.Printf("error caching %s: %+v\n", key, err)
+	}
+
+	return body, nil
+}
+
+func cacheKey(branch, path string) string {
+	return branch + "/" + path
+}
+
+func cacheTTL(branch string) time.Duration {
+	if branch == "main" {
+		return MainBranchTTL
+	}
+	return TagBranchTTL
+}
+
+// fetchUpstream issues a plain GET, with no conditional revalidation.
+func fetchUpstream(ctx context.Context, path string, branch string) ([]byte, error) {
+	body, _, _, err := fetchConditional(ctx, path, branch, "")
+	return body, err
+}
+
+// fetchConditional issues a GET against raw.githubusercontent.com, sending
+// If-None-Match when etag is set. notModified reports a 304 response, in
+// which case body is nil and the caller should use its cached copy.
+func fetchConditional(ctx context.Context, path string, branch string, etag string) (body []byte, newETag string, notModified bool, err error) {
+	cli := fetch.NewClient()
+
+	url := fmt.Sprintf(REPO_URL, branch, path)
+
+	r, err := fetch.NewRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+	r.Header.Set("User-Agent", USER_AGENT)
+	if etag != "" {
+		r.Header.Set("If-None-Match", etag)
+	}
+
+	res, err := cli.Do(r, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	if res.StatusCode == http.StatusNotModified {
+		return nil, etag, true, nil
+	}
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, "", false, errors.New(FILE_NOT_FOUND_ERROR_MESSAGE)
+	}
+
+	buf := new(bytes.Buffer)
+	io.Copy(buf, res.Body)
+
+	return buf.Bytes(), res.Header.Get("ETag"), false, nil
+}
+
+func putCacheEntry(ctx context.Context, ns *kv.Namespace, key string, branch string, etag string, body []byte) error {
+	raw, err := json.Marshal(cacheEntry{ETag: etag, Body: body})
+	if err != nil {
+		return err
+	}
+
+	return ns.PutString(ctx, key, string(raw), &kv.PutOptions{
+		ExpirationTTL: cacheTTL(branch),
+	})
+}
+
+// purgeHandler invalidates a cached branch/path so the next request re-fetches
+// from upstream. Gated by a shared secret so a GitHub push webhook can call
+// it directly.
+func purgeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	secret := workers.Getenv(PurgeSecretEnv)
+	given := r.Header.Get("Authorization")
+	if secret == "" || subtle.ConstantTimeCompare([]byte(given), []byte("Bearer "+secret)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		http.Error(w, "missing path", http.StatusBadRequest)
+		return
+	}
+
+	branch := r.URL.Query().Get("branch")
+	if branch == "" {
+		branch = "main"
+	}
+
+	ns, err := kv.NewNamespace(KVBindingName)
+	if err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	if err := ns.Delete(r.Context(), cacheKey(branch, path+".md")); err != nil {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+// Package frontmatter parses a page's leading metadata block, shared by the
+// wikiplum build (main) and the Cloudflare Pages Function (functions/main)
+// so the two deployables can't drift out of sync on how a page's metadata
+// is typed and decoded.
+package frontmatter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	yamlDelim = "---"
+	tomlDelim = "+++"
+)
+
+// Frontmatter is a page's typed metadata block. Extra holds any keys that
+// don't map to one of the named fields, keyed by their raw name.
+type Frontmatter struct {
+	Title     string         `yaml:"title" toml:"title" json:"title"`
+	Date      string         `yaml:"date" toml:"date" json:"date"`
+	Updated   string         `yaml:"updated" toml:"updated" json:"updated"`
+	Tags      []string       `yaml:"tags" toml:"tags" json:"tags"`
+	Draft     bool           `yaml:"draft" toml:"draft" json:"draft"`
+	Summary   string         `yaml:"summary" toml:"summary" json:"summary"`
+	Layout    string         `yaml:"layout" toml:"layout" json:"layout"`
+	Aliases   []string       `yaml:"aliases" toml:"aliases" json:"aliases"`
+	Weight    int            `yaml:"weight" toml:"weight" json:"weight"`
+	Collapsed bool           `yaml:"collapsed" toml:"collapsed" json:"collapsed"`
+	Extra     map[string]any `yaml:"-" toml:"-" json:"-"`
+}
+
+var knownKeys = map[string]struct{}{
+	"title": {}, "date": {}, "updated": {}, "tags": {},
+	"draft": {}, "summary": {}, "layout": {}, "aliases": {},
+	"weight": {}, "collapsed": {},
+}
+
+// Parse splits md into its frontmatter block and body, auto detecting the
+// block's format from its opening delimiter: --- for YAML, +++ for TOML,
+// and a bare { for a JSON object. A file with none of these delimiters has
+// no frontmatter and is returned unchanged as the body.
+func Parse(md []byte) (Frontmatter, []byte, error) {
+	trimmed := strings.TrimLeft(string(md), "\ufeff \t\r\n")
+
+	switch {
+	case strings.HasPrefix(trimmed, yamlDelim):
+		return parseDelimited(trimmed, yamlDelim, yaml.Unmarshal)
+	case strings.HasPrefix(trimmed, tomlDelim):
+		return parseDelimited(trimmed, tomlDelim, toml.Unmarshal)
+	case strings.HasPrefix(trimmed, "{"):
+		return parseJSON(trimmed)
+	default:
+		return Frontmatter{}, md, nil
+	}
+}
+
+func parseDelimited(content, delim string, unmarshal func([]byte, any) error) (Frontmatter, []byte, error) {
+	rest := strings.TrimPrefix(content, delim)
+
+	end := strings.Index(rest, delim)
+	if end == -1 {
+		return Frontmatter{}, []byte(content), fmt.Errorf("frontmatter: missing closing %q", delim)
+	}
+
+	block := []byte(rest[:end])
+	body := rest[end+len(delim):]
+
+	var fm Frontmatter
+	if err := unmarshal(block, &fm); err != nil {
+		return Frontmatter{}, []byte(content), err
+	}
+
+	var raw map[string]any
+	if err := unmarshal(block, &raw); err != nil {
+		return Frontmatter{}, []byte(content), err
+	}
+	fm.Extra = extraFields(raw)
+
+	return fm, []byte(strings.TrimLeft(body, "\r\n")), nil
+}
+
+func parseJSON(content string) (Frontmatter, []byte, error) {
+	dec := json.NewDecoder(strings.NewReader(content))
+
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return Frontmatter{}, []byte(content), err
+	}
+
+	block, err := json.Marshal(raw)
+	if err != nil {
+		return Frontmatter{}, []byte(content), err
+	}
+
+	var fm Frontmatter
+	if err := json.Unmarshal(block, &fm); err != nil {
+		return Frontmatter{}, []byte(content), err
+	}
+	fm.Extra = extraFields(raw)
+
+	body := content[dec.InputOffset():]
+	return fm, []byte(strings.TrimLeft(body, "\r\n")), nil
+}
+
+func extraFields(raw map[string]any) map[string]any {
+	var extra map[string]any
+	for k, v := range raw {
+		if _, ok := knownKeys[k]; ok {
+			continue
+		}
+		if extra == nil {
+			extra = make(map[string]any)
+		}
+		extra[k] = v
+	}
+	return extra
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// mdLinkTransformer rewrites ast.Link destinations ending in .md so that
+// cross-page links keep working once rendered to HTML. It walks the parsed
+// AST rather than string-replacing the raw markdown, so ".md" text inside
+// code blocks or prose is left untouched.
+type mdLinkTransformer struct{}
+
+func (mdLinkTransformer) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+
+		link, ok := n.(*ast.Link)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+
+		link.Destination = rewriteMdLink(link.Destination)
+		return ast.WalkContinue, nil
+	})
+}
+
+// rewriteMdLink rewrites a link destination whose path portion ends in .md,
+// preserving any trailing #fragment or ?query so links like b.md#intro
+// still resolve after the path is rewritten.
+func rewriteMdLink(dest []byte) []byte {
+	path := dest
+	suffix := []byte{}
+	if i := bytes.IndexAny(dest, "#?"); i != -1 {
+		path = dest[:i]
+		suffix = append([]byte{}, dest[i:]...)
+	}
+
+	if !bytes.HasSuffix(path, []byte(".md")) {
+		return dest
+	}
+
+	path = bytes.TrimSuffix(path, []byte(".md"))
+	if !PrettyURLs {
+		path = append(path, []byte(".html")...)
+	}
+
+	return append(path, suffix...)
+}
+
+// mdLinks is the goldmark extension that installs mdLinkTransformer.
+type mdLinks struct{}
+
+func (mdLinks) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(mdLinkTransformer{}, 100),
+	))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/starillume/wikiplum/internal/frontmatter"
+)
+
+// SectionIndexFile holds a directory's section metadata (title, weight,
+// collapsed) but is never rendered to its own page.
+const SectionIndexFile = "_index.md"
+
+// NavItem is one entry in the rendered sidebar tree. Collapsed only applies
+// to section headers (nodes with Children) and controls their default
+// render state.
+type NavItem struct {
+	Title     string
+	Link      string
+	Children  []NavItem
+	Active    bool
+	Collapsed bool
+}
+
+// navNode is the intermediate tree built while walking content, carrying the
+// weight and collapsed state used to sort and render NavItems.
+type navNode struct {
+	title     string
+	link      string
+	weight    int
+	collapsed bool
+	children  []*navNode
+}
+
+// generateSidebar builds the nav tree rooted at root, sorted per directory
+// by weight then title, with the node(s) leading to currentPath marked
+// Active.
+func generateSidebar(root string, currentPath string) []NavItem {
+	tree, err := buildNavTree(root)
+	if err != nil {
+		return nil
+	}
+
+	return toNavItems(tree.children, pageLink(currentPath))
+}
+
+func buildNavTree(dir string) (*navNode, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	node := &navNode{title: filepath.Base(dir)}
+
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+
+		if e.IsDir() {
+			child, err := buildNavTree(path)
+			if err != nil {
+				return nil, err
+			}
+			if child.link == "" && len(child.children) == 0 {
+				continue
+			}
+			node.children = append(node.children, child)
+			continue
+		}
+
+		if !strings.HasSuffix(e.Name(), ".md") {
+			continue
+		}
+
+		if e.Name() == SectionIndexFile {
+			title, weight, collapsed := readSectionMeta(path)
+			if title != "" {
+				node.title = title
+			}
+			node.weight = weight
+			node.collapsed = collapsed
+			continue
+		}
+
+		title, weight, draft := readLeafMeta(path)
+		if draft && !LiveReload {
+			continue
+		}
+
+		if e.Name() == RootPage+".md" {
+			node.link = pageLink(path)
+			if node.title == filepath.Base(dir) && title != "" {
+				node.title = title
+			}
+			continue
+		}
+
+		node.children = append(node.children, &navNode{
+			title:  title,
+			link:   pageLink(path),
+			weight: weight,
+		})
+	}
+
+	sortNavNodes(node.children)
+	return node, nil
+}
+
+func sortNavNodes(nodes []*navNode) {
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].weight != nodes[j].weight {
+			return nodes[i].weight < nodes[j].weight
+		}
+		return nodes[i].title < nodes[j].title
+	})
+}
+
+// readLeafMeta also reports whether the page is a draft, so buildNavTree can
+// omit it from the sidebar the same way buildPage skips writing it.
+func readLeafMeta(path string) (title string, weight int, draft bool) {
+	fallback := filepath.Base(strings.TrimSuffix(path, ".md"))
+
+	md, err := os.ReadFile(path)
+	if err != nil {
+		return fallback, 0, false
+	}
+
+	fm, _, err := frontmatter.Parse(md)
+	if err != nil {
+		return fallback, 0, false
+	}
+
+	if fm.Title == "" {
+		return fallback, fm.Weight, fm.Draft
+	}
+	return fm.Title, fm.Weight, fm.Draft
+}
+
+func readSectionMeta(path string) (title string, weight int, collapsed bool) {
+	md, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, false
+	}
+
+	fm, _, err := frontmatter.Parse(md)
+	if err != nil {
+		return "", 0, false
+	}
+
+	return fm.Title, fm.Weight, fm.Collapsed
+}
+
+// toNavItems converts the internal tree to NavItems, marking a node Active
+// when it is, or has a descendant that is, the current page.
+func toNavItems(nodes []*navNode, currentLink string) []NavItem {
+	items := make([]NavItem, 0, len(nodes))
+
+	for _, n := range nodes {
+		children := toNavItems(n.children, currentLink)
+
+		active := n.link != "" && n.link == currentLink
+		for _, c := range children {
+			active = active || c.Active
+		}
+
+		items = append(items, NavItem{
+			Title:     n.title,
+			Link:      n.link,
+			Children:  children,
+			Active:    active,
+			Collapsed: n.collapsed,
+		})
+	}
+
+	return items
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"flag"
 	"fmt"
 	"html/template"
 	"io"
@@ -10,49 +12,96 @@ import (
 	"strings"
 
 	"github.com/yuin/goldmark"
+
+	"github.com/starillume/wikiplum/internal/frontmatter"
 )
 
 const (
 	ContentPath   = "content"
-	BuildPath     = "build"
 	TemplatesPath = "templates"
 	BaseTemplate  = "base.html"
 	PageTemplate  = "page.html"
 	RootPage      = "index"
 )
 
-type PageData struct {
-	Title   string
-	HTML    template.HTML
-	Sidebar []NavItem
-	Rel     string
-}
+// BuildPath is the output directory for a build. It is a var rather than a
+// const so the dev server can redirect builds into a temp directory.
+var BuildPath = "build"
+
+// PrettyURLs controls whether outputPath, mdLinkTransformer and
+// generateSidebar emit build/foo/index.html links (served at /foo/) instead
+// of build/foo.html. It is set once in main from the --pretty-urls /
+// --ugly-urls flags and the site config.
+var PrettyURLs bool
 
-type NavItem struct {
-	Title string
-	Link  string
+var markdownConverter = goldmark.New(goldmark.WithExtensions(mdLinks{}))
+
+type PageData struct {
+	Title       string
+	HTML        template.HTML
+	Sidebar     []NavItem
+	Rel         string
+	Frontmatter frontmatter.Frontmatter
 }
 
 func main() {
-	tmpl, err := mustLoadTemplates()
+	pretty := flag.Bool("pretty-urls", false, "emit build/foo/index.html instead of build/foo.html")
+	ugly := flag.Bool("ugly-urls", false, "force build/foo.html even if the site config enables pretty URLs")
+	flag.Parse()
+
+	cfg, err := loadSiteConfig()
 	if err != nil {
-		fmt.Println("error loading templates: ", err)
+		fmt.Println("error loading site config: ", err)
 		os.Exit(1)
 	}
 
-	if err := buildPages(tmpl); err != nil {
-		fmt.Println("error building site: ", err)
+	PrettyURLs = cfg.PrettyURLs || *pretty
+	if *ugly {
+		PrettyURLs = false
+	}
+
+	if flag.Arg(0) == "dev" {
+		if err := runDev(cfg); err != nil {
+			fmt.Println("error running dev server: ", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := build(cfg); err != nil {
+		fmt.Println(err)
 		os.Exit(1)
 	}
+}
 
+func build(cfg SiteConfig) error {
 	if err := copyStatic(); err != nil {
-		fmt.Println("error copying static files: ", err)
-		os.Exit(1)
+		return fmt.Errorf("error copying static files: %w", err)
+	}
+
+	tmpl, err := mustLoadTemplates()
+	if err != nil {
+		return fmt.Errorf("error loading templates: %w", err)
+	}
+
+	if err := buildPages(tmpl); err != nil {
+		return fmt.Errorf("error building site: %w", err)
+	}
+
+	if err := buildFeed(cfg); err != nil {
+		return fmt.Errorf("error building feed: %w", err)
 	}
+
+	return nil
 }
 
+// mustLoadTemplates parses the site templates with the asset FuncMap
+// installed, so {{ asset "css/site.css" }} resolves to the fingerprinted
+// path copyStatic just produced.
 func mustLoadTemplates() (*template.Template, error) {
-	tmpl, err := template.ParseFiles(
+	tmpl, err := template.New(BaseTemplate).Funcs(template.FuncMap{
+		"asset": assetPath,
+	}).ParseFiles(
 		filepath.Join(TemplatesPath, BaseTemplate),
 		filepath.Join(TemplatesPath, PageTemplate),
 	)
@@ -64,86 +113,198 @@ func mustLoadTemplates() (*template.Template, error) {
 
 func buildPages(tmpl *template.Template) error {
 	return filepath.WalkDir(ContentPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".md") {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".md") || filepath.Base(path) == SectionIndexFile {
 			return err
 		}
 
-		outPath := outputPath(path)
+		return buildPage(tmpl, path)
+	})
+}
+
+// buildPage renders and writes a single markdown file. It is factored out of
+// buildPages so the dev server can rebuild just the file that changed.
+// Draft pages are skipped outside of the dev server.
+func buildPage(tmpl *template.Template, path string) error {
+	md, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	fm, body, err := frontmatter.Parse(md)
+	if err != nil {
+		return err
+	}
+
+	if fm.Draft && !LiveReload {
+		return nil
+	}
+
+	outPath := outputPath(path)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return err
+	}
+
+	html, err := renderMarkdown(body)
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(filepath.Dir(path), ContentPath)
+	if err != nil {
+		return err
+	}
+
+	title := fm.Title
+	if title == "" {
+		title = filepath.Base(strings.TrimSuffix(path, ".md"))
+	}
+
+	data := PageData{
+		Title:       title,
+		HTML:        template.HTML(html),
+		Sidebar:     generateSidebar(ContentPath, path),
+		Rel:         rel,
+		Frontmatter: fm,
+	}
+
+	if err := writePage(outPath, tmpl, data); err != nil {
+		return err
+	}
+
+	return writeAliases(fm.Aliases, pageLink(path))
+}
+
+func outputPath(mdPath string) string {
+	rel, _ := filepath.Rel(ContentPath, mdPath)
+	rel = strings.TrimSuffix(rel, ".md")
+
+	if !PrettyURLs {
+		return filepath.Join(BuildPath, rel+".html")
+	}
+
+	if filepath.Base(rel) == RootPage {
+		rel = filepath.Dir(rel)
+	}
+	return filepath.Join(BuildPath, rel, "index.html")
+}
+
+// pageLink returns the canonical site-relative URL for a markdown page,
+// following the same PrettyURLs rules as outputPath.
+func pageLink(mdPath string) string {
+	rel, _ := filepath.Rel(ContentPath, mdPath)
+	rel = strings.TrimSuffix(rel, ".md")
+
+	if !PrettyURLs {
+		return "/" + filepath.ToSlash(rel) + ".html"
+	}
+
+	if filepath.Base(rel) == RootPage {
+		rel = filepath.Dir(rel)
+	}
+	if rel == "." {
+		return "/"
+	}
+	return "/" + filepath.ToSlash(rel) + "/"
+}
+
+const redirectStub = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="0; url=%[1]s">
+<link rel="canonical" href="%[1]s">
+</head>
+<body>
+<p>Redirecting to <a href="%[1]s">%[1]s</a>...</p>
+</body>
+</html>
+`
+
+// writeAliases writes a redirect stub at each alias path, pointing to the
+// page's canonical link. Aliases that escape BuildPath (e.g. via "..") are
+// rejected rather than written, since they come straight from untrusted
+// page frontmatter.
+func writeAliases(aliases []string, link string) error {
+	for _, alias := range aliases {
+		outPath, ok := aliasOutputPath(alias)
+		if !ok {
+			fmt.Printf("skipping invalid alias %q: escapes %s\n", alias, BuildPath)
+			continue
+		}
+
 		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
 			return err
 		}
 
-		html, err := renderMarkdown(path)
+		f, err := os.Create(outPath)
 		if err != nil {
 			return err
 		}
 
-		rel, err := filepath.Rel(filepath.Dir(path), ContentPath)
+		_, err = fmt.Fprintf(f, redirectStub, link)
+		f.Close()
 		if err != nil {
 			return err
 		}
-
-		data := PageData{
-			Title:   filepath.Base(strings.TrimSuffix(path, ".md")),
-			HTML:    template.HTML(html),
-			Sidebar: generateSidebar(ContentPath, path),
-			Rel:     rel,
-		}
-
-		return writePage(outPath, tmpl, data)
-	})
+	}
+	return nil
 }
 
-func outputPath(mdPath string) string {
-	rel, _ := filepath.Rel(ContentPath, mdPath)
-	rel = strings.TrimSuffix(rel, ".md")
-	return filepath.Join(BuildPath, rel+".html")
-}
+// aliasOutputPath resolves alias to a path under BuildPath, reporting ok =
+// false if the alias (e.g. "../../etc/passwd") would Clean to somewhere
+// outside it.
+func aliasOutputPath(alias string) (path string, ok bool) {
+	alias = filepath.Clean(strings.Trim(alias, "/"))
 
-func mdLinkToHTML(md []byte) []byte {
-	return []byte(strings.ReplaceAll(string(md), ".md", ".html"))
-}
+	rel := alias + ".html"
+	if PrettyURLs {
+		rel = filepath.Join(alias, "index.html")
+	}
+	outPath := filepath.Join(BuildPath, rel)
 
-func renderMarkdown(mdPath string) (string, error) {
-	md, err := os.ReadFile(mdPath)
+	buildAbs, err := filepath.Abs(BuildPath)
 	if err != nil {
-		return "", err
+		return "", false
+	}
+	outAbs, err := filepath.Abs(outPath)
+	if err != nil {
+		return "", false
+	}
+
+	if outAbs != buildAbs && !strings.HasPrefix(outAbs, buildAbs+string(filepath.Separator)) {
+		return "", false
 	}
 
+	return outPath, true
+}
+
+func renderMarkdown(body []byte) (string, error) {
 	var html strings.Builder
-	if err := goldmark.Convert(mdLinkToHTML(md), &html); err != nil {
+	if err := markdownConverter.Convert(body, &html); err != nil {
 		return "", err
 	}
 	return html.String(), nil
 }
 
 func writePage(outPath string, tmpl *template.Template, data PageData) error {
-	f, err := os.Create(outPath)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, BaseTemplate, data); err != nil {
 		return err
 	}
-	defer f.Close()
 
-	return tmpl.ExecuteTemplate(f, BaseTemplate, data)
-}
+	html := buf.String()
+	if LiveReload {
+		html = injectLiveReload(html)
+	}
 
-func copyStatic() error {
-	src := "static"
-	dst := filepath.Join(BuildPath, "static")
-	if err := os.MkdirAll(dst, 0755); err != nil {
+	f, err := os.Create(outPath)
+	if err != nil {
 		return err
 	}
+	defer f.Close()
 
-	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil || info.IsDir() {
-			return err
-		}
-
-		rel, _ := filepath.Rel(src, path)
-		outPath := filepath.Join(dst, rel)
-
-		return copyFile(path, outPath)
-	})
+	_, err = f.WriteString(html)
+	return err
 }
 
 func copyFile(src string, dst string) error {
@@ -162,31 +323,3 @@ func copyFile(src string, dst string) error {
 	_, err = io.Copy(out, in)
 	return err
 }
-
-func generateSidebar(root string, currentPath string) []NavItem {
-    var items []NavItem
-    filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-        if err != nil || d.IsDir() || strings.Contains(path, RootPage) || !strings.HasSuffix(path, ".md") {
-            return err
-        }
-
-        relRoot, _ := filepath.Rel(filepath.Dir(currentPath), root)
-        rel, _ := filepath.Rel(root, path)
-
-        var link string
-        if relRoot == "." {
-            link = rel
-        } else {
-            link = filepath.Join(relRoot, rel)
-        }
-
-        link = strings.TrimSuffix(link, ".md")
-
-        items = append(items, NavItem{
-            Title: filepath.Base(link),
-            Link:  link + ".html",
-        })
-        return nil
-    })
-    return items
-}